@@ -5,17 +5,58 @@ package eudore
 */
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
-	"syscall"
-	// etcd "github.com/coreos/etcd/client"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcl"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// reEnvExpand 匹配'${VAR:-default}'格式的环境变量占位符。
+var reEnvExpand = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// GlobalConfigDecodeFunc 保存配置反序列化函数，以文件后缀名或http Content-Type为key，用于ConfigParseConfig按'keys.configtype'选择解析方式。
+var GlobalConfigDecodeFunc = map[string]func([]byte, interface{}) error{
+	"json":                   json.Unmarshal,
+	"application/json":       json.Unmarshal,
+	"yaml":                   yaml.Unmarshal,
+	"yml":                    yaml.Unmarshal,
+	"application/yaml":       yaml.Unmarshal,
+	"text/yaml":              yaml.Unmarshal,
+	"toml":                   toml.Unmarshal,
+	"application/toml":       toml.Unmarshal,
+	"hcl":                    hcl.Unmarshal,
+	"application/hcl":        hcl.Unmarshal,
+	"properties":             unmarshalProperties,
+	"text/x-java-properties": unmarshalProperties,
+}
+
+// systemd sd_notify协议相关环境变量名称和socket激活的起始文件描述符。
+const (
+	EnvSystemdListenPid    = "LISTEN_PID"
+	EnvSystemdListenFds    = "LISTEN_FDS"
+	EnvSystemdNotifySocket = "NOTIFY_SOCKET"
+	EnvSystemdWatchdogUsec = "WATCHDOG_USEC"
+	systemdListenFdsStart  = 3
 )
 
 // 保存全局函数
@@ -23,22 +64,49 @@ var (
 	GlobalRouterCheckFunc    = make(map[string]RouterCheckFunc)
 	GlobalRouterNewCheckFunc = make(map[string]RouterNewCheckFunc)
 	GlobalConfigReadFunc     = make(map[string]ConfigReadFunc)
+	GlobalConfigWatchFunc    = make(map[string]ConfigWatchFunc)
 )
 
 func init() {
 	// RouterCheckFunc
 	GlobalRouterCheckFunc["isnum"] = RouterCheckFuncIsnum
+	GlobalRouterCheckFunc["email"] = RouterCheckFuncEmail
+	GlobalRouterCheckFunc["ipv4"] = RouterCheckFuncIPv4
+	GlobalRouterCheckFunc["ipv6"] = RouterCheckFuncIPv6
 	// RouterNewCheckFunc
 	GlobalRouterNewCheckFunc["min"] = RouterNewCheckFuncMin
+	GlobalRouterNewCheckFunc["max"] = RouterNewCheckFuncMax
+	GlobalRouterNewCheckFunc["range"] = RouterNewCheckFuncRange
+	GlobalRouterNewCheckFunc["len"] = RouterNewCheckFuncLen
 	GlobalRouterNewCheckFunc["regexp"] = RouterNewCheckFuncRegexp
+	GlobalRouterNewCheckFunc["uuid"] = RouterNewCheckFuncUUID
+	GlobalRouterNewCheckFunc["date"] = RouterNewCheckFuncDate
+	GlobalRouterNewCheckFunc["enum"] = RouterNewCheckFuncEnum
+	GlobalRouterNewCheckFunc["prefix"] = RouterNewCheckFuncPrefix
+	GlobalRouterNewCheckFunc["suffix"] = RouterNewCheckFuncSuffix
+	GlobalRouterNewCheckFunc["contains"] = RouterNewCheckFuncContains
 	// ConfigReadFunc
 	GlobalConfigReadFunc["default"] = ConfigReadFile
 	GlobalConfigReadFunc["file"] = ConfigReadFile
 	GlobalConfigReadFunc["https"] = ConfigReadHTTP
 	GlobalConfigReadFunc["http"] = ConfigReadHTTP
+	GlobalConfigReadFunc["etcd"] = ConfigReadEtcd
+	GlobalConfigReadFunc["etcdv3"] = ConfigReadEtcd
+	GlobalConfigReadFunc["consul"] = ConfigReadConsul
+	// ConfigWatchFunc
+	GlobalConfigWatchFunc["etcd"] = ConfigWatchEtcd
+	GlobalConfigWatchFunc["etcdv3"] = ConfigWatchEtcd
+	GlobalConfigWatchFunc["consul"] = ConfigWatchConsul
 }
 
-// ConfigParseRead 函数使用'keys.config'读取配置内容，并使用[]byte类型保存到'keys.configdata'。
+// ConfigWatchFunc 定义配置监听函数，监听path对应的配置来源，内容变化时调用fn，返回取消监听的函数。
+type ConfigWatchFunc func(path string, fn func([]byte)) (func(), error)
+
+// httpConfigContentType 记录ConfigReadHTTP最近一次成功请求的响应Content-Type，
+// 在ConfigParseRead中path没有文件后缀时作为'keys.configtype'的兜底来源。
+var httpConfigContentType string
+
+// ConfigParseRead 函数使用'keys.config'读取配置内容，并使用[]byte类型保存到'keys.configdata'，同时记录'keys.configpath'和'keys.configtype'。
 func ConfigParseRead(c Config) error {
 	errs := NewErrors()
 	for _, path := range GetArrayString(c.Get("keys.config")) {
@@ -49,10 +117,16 @@ func ConfigParseRead(c Config) error {
 			// use default read func
 			fn = GlobalConfigReadFunc["default"]
 		}
+		httpConfigContentType = ""
 		data, err := fn(path)
 		if err == nil {
-			c.Set("keys.configdata", data)
+			configtype := configExt(path)
+			if configtype == "" {
+				configtype = httpConfigContentType
+			}
+			c.Set("keys.configdata", envExpand(data))
 			c.Set("keys.configpath", path)
+			c.Set("keys.configtype", configtype)
 			return nil
 		}
 		errs.HandleError(err)
@@ -60,23 +134,75 @@ func ConfigParseRead(c Config) error {
 	return errs.GetError()
 }
 
-// ConfigParseConfig 函数获得'keys.configdata'的内容解析配置。
+// configExt 函数从path中提取文件后缀名，忽略查询字符串，用于推断配置类型。
+func configExt(path string) string {
+	path = strings.SplitN(path, "?", 2)[0]
+	path = path[strings.LastIndex(path, "/")+1:]
+	pos := strings.LastIndex(path, ".")
+	if pos == -1 {
+		return ""
+	}
+	return path[pos+1:]
+}
+
+// envExpand 函数替换data中'${VAR:-default}'格式的环境变量占位符，VAR未设置时使用default，default缺省时替换为空字符串。
+func envExpand(data []byte) []byte {
+	return reEnvExpand.ReplaceAllFunc(data, func(m []byte) []byte {
+		sub := reEnvExpand.FindSubmatch(m)
+		val, ok := os.LookupEnv(string(sub[1]))
+		if ok {
+			return []byte(val)
+		}
+		if len(sub[2]) > 2 {
+			return sub[2][2:]
+		}
+		return nil
+	})
+}
+
+// unmarshalProperties 函数解析properties格式配置内容，每行为'key=value'，通过Config.Set设置值。
+func unmarshalProperties(data []byte, v interface{}) error {
+	c, ok := v.(Config)
+	if !ok {
+		return fmt.Errorf("unmarshal properties config, target is not eudore.Config")
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		c.Set(split2byte(line, '='))
+	}
+	return nil
+}
+
+// ConfigParseConfig 函数获得'keys.configdata'的内容，依据'keys.configtype'从GlobalConfigDecodeFunc选择解析函数解析配置，未匹配到类型时使用json解析。
 func ConfigParseConfig(c Config) error {
 	data := c.Get("keys.configdata")
 	if data == nil {
 		return nil
 	}
-	err := json.Unmarshal(data.([]byte), c)
-	return err
+
+	fn, ok := GlobalConfigDecodeFunc[GetString(c.Get("keys.configtype"))]
+	if !ok {
+		fn = json.Unmarshal
+	}
+	return fn(data.([]byte), c)
 }
 
+// keyConfigOverrides 保存ConfigParseArgs/ConfigParseEnvs设置过的配置key，ConfigParseMods合并mods配置后会重新应用这些key的值，
+// 保证命令行参数和环境变量的优先级始终高于mods配置。
+const keyConfigOverrides = "keys.configoverrides"
+
 // ConfigParseArgs 函数使用参数设置配置，参数使用--为前缀。
 func ConfigParseArgs(c Config) (err error) {
 	for _, str := range os.Args[1:] {
 		if !strings.HasPrefix(str, "--") {
 			continue
 		}
-		c.Set(split2byte(str[2:], '='))
+		k, v := split2byte(str[2:], '=')
+		c.Set(k, v)
+		markConfigOverride(c, k)
 	}
 	return
 }
@@ -88,14 +214,28 @@ func ConfigParseEnvs(c Config) error {
 			k, v := split2byte(value, '=')
 			k = strings.ToLower(strings.Replace(k, "_", ".", -1))[4:]
 			c.Set(k, v)
+			markConfigOverride(c, k)
 		}
 	}
 	return nil
 }
 
-// ConfigParseMods 函数从'enable'项获得使用的模式的数组字符串，从'mods.xxx'加载配置。
+// markConfigOverride 函数把key记录到keyConfigOverrides，用于ConfigParseMods合并完mods配置后重新应用这些被显式设置过的key。
+func markConfigOverride(c Config, key string) {
+	keys := GetArrayString(c.Get(keyConfigOverrides))
+	for _, k := range keys {
+		if k == key {
+			return
+		}
+	}
+	c.Set(keyConfigOverrides, append(keys, key))
+}
+
+// ConfigParseMods 函数从'enable'项获得使用的模式数组，额外追加自动探测到的运行环境模式，
+// 按顺序把'mods.xxx'配置深度合并到根配置，数组越靠后的模式优先级越高。
 //
-// 默认会加载OS mod,如果是docker环境下使用docker模式。
+// 合并过程中列表默认整体替换，mods配置也可以用{'!strategy': 'append|prepend|merge-by-key:field', '!value': [...]}
+// 的形式声明合并策略；合并结束后会重新应用ConfigParseArgs/ConfigParseEnvs设置过的key，使它们始终保持最高优先级。
 func ConfigParseMods(c Config) error {
 	mod, ok := c.Get("enable").([]string)
 	if !ok {
@@ -110,22 +250,150 @@ func ConfigParseMods(c Config) error {
 		}
 	}
 	mod = append(mod, getOS())
+
+	overrides := make(map[string]interface{})
+	for _, key := range GetArrayString(c.Get(keyConfigOverrides)) {
+		overrides[key] = c.Get(key)
+	}
+
+	data := c.Get("")
 	for _, i := range mod {
-		ConvertTo(c.Get("mods."+i), c.Get(""))
+		data = deepMerge(data, c.Get("mods."+i))
+	}
+	ConvertTo(data, c.Get(""))
+
+	for key, val := range overrides {
+		c.Set(key, val)
 	}
 	return nil
 }
 
+// deepMerge 函数把src深度合并进dst并返回合并结果：map按key递归合并；
+// list使用mergeList按声明的策略合并；其他类型直接使用src覆盖dst，src为nil时保留dst不变。
+func deepMerge(dst, src interface{}) interface{} {
+	if src == nil {
+		return dst
+	}
+	switch val := src.(type) {
+	case map[string]interface{}:
+		if _, ok := val["!value"]; ok {
+			return mergeList(dst, val)
+		}
+		d, ok := dst.(map[string]interface{})
+		if !ok {
+			d = make(map[string]interface{})
+		}
+		for k, v := range val {
+			d[k] = deepMerge(d[k], v)
+		}
+		return d
+	case []interface{}:
+		return mergeList(dst, val)
+	default:
+		return src
+	}
+}
+
+// mergeList 函数按src声明的列表合并策略合并dst和src解析出的元素。
+//
+// src可以是普通列表（默认replace策略，整体替换dst），也可以是
+// {'!strategy': 'replace|append|prepend|merge-by-key:field', '!value': [...]}形式显式声明策略。
+func mergeList(dst, src interface{}) interface{} {
+	strategy, list := parseListStrategy(src)
+	dl, _ := dst.([]interface{})
+
+	switch {
+	case strategy == "append":
+		return append(append([]interface{}{}, dl...), list...)
+	case strategy == "prepend":
+		return append(append([]interface{}{}, list...), dl...)
+	case strings.HasPrefix(strategy, "merge-by-key:"):
+		return mergeListByKey(dl, list, strings.TrimPrefix(strategy, "merge-by-key:"))
+	default:
+		return list
+	}
+}
+
+// parseListStrategy 函数从src中解析列表合并策略和实际的元素列表，src不是'!value'声明形式时视为'replace'策略。
+func parseListStrategy(src interface{}) (string, []interface{}) {
+	if m, ok := src.(map[string]interface{}); ok {
+		list, _ := m["!value"].([]interface{})
+		strategy, _ := m["!strategy"].(string)
+		if strategy == "" {
+			strategy = "replace"
+		}
+		return strategy, list
+	}
+	list, _ := src.([]interface{})
+	return "replace", list
+}
+
+// mergeListByKey 函数按key字段合并两个对象列表：src中的元素如果能在dst中找到key值相同的对象，则深度合并进该对象，否则追加到结果末尾。
+func mergeListByKey(dst, src []interface{}, key string) []interface{} {
+	result := append([]interface{}{}, dst...)
+	index := make(map[interface{}]int, len(result))
+	for i, item := range result {
+		if m, ok := item.(map[string]interface{}); ok {
+			index[m[key]] = i
+		}
+	}
+
+	for _, item := range src {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			result = append(result, item)
+			continue
+		}
+		if i, ok := index[m[key]]; ok {
+			result[i] = deepMerge(result[i], item)
+			continue
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// getOS 函数探测当前运行环境，用于ConfigParseMods自动追加对应的mod名称：
+// 依次检测kubernetes、docker、systemd-nspawn等容器运行时特征，均未检测到时返回runtime.GOOS。
 func getOS() string {
+	if inKubernetes() {
+		return "kubernetes"
+	}
 	// check docker
 	_, err := os.Stat("/.dockerenv")
 	if err == nil || !os.IsNotExist(err) {
 		return "docker"
 	}
+	if container := containerEngine(); container != "" {
+		return container
+	}
 	// 返回默认OS
 	return runtime.GOOS
 }
 
+// inKubernetes 函数读取'/proc/1/cgroup'，检测cgroup路径中是否包含'kubepods'或'containerd'，用于判断当前是否运行在kubernetes pod中。
+func inKubernetes() bool {
+	data, err := ioutil.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(data), "kubepods") || strings.Contains(string(data), "containerd")
+}
+
+// containerEngine 函数读取'/proc/1/environ'中的'container='环境变量，用于识别systemd-nspawn等容器运行时。
+func containerEngine() string {
+	data, err := ioutil.ReadFile("/proc/1/environ")
+	if err != nil {
+		return ""
+	}
+	for _, kv := range strings.Split(string(data), "\x00") {
+		if strings.HasPrefix(kv, "container=") {
+			return strings.TrimPrefix(kv, "container=")
+		}
+	}
+	return ""
+}
+
 // ConfigParseHelp 函数测试配置内容，如果存在'keys.help'项会使用JSON标准化输出配置到标准输出。
 func ConfigParseHelp(c Config) error {
 	ok := c.Get("keys.help") != nil
@@ -149,6 +417,8 @@ func ConfigReadFile(path string) ([]byte, error) {
 }
 
 // ConfigReadHTTP Send http request get config info
+//
+// 读取成功后会把响应的Content-Type记录到httpConfigContentType，供ConfigParseRead在path没有文件后缀时兜底判断'keys.configtype'。
 func ConfigReadHTTP(path string) ([]byte, error) {
 	resp, err := http.Get(path)
 	if err != nil {
@@ -156,47 +426,341 @@ func ConfigReadHTTP(path string) ([]byte, error) {
 	}
 	defer resp.Body.Close()
 	data, err := ioutil.ReadAll(resp.Body)
+	if err == nil {
+		httpConfigContentType = strings.TrimSpace(strings.SplitN(resp.Header.Get("Content-Type"), ";", 2)[0])
+	}
 	return data, err
 }
 
-// example: etcd://127.0.0.1:2379/config
-/*func ConfigReadEtcd(path string) (string, error) {
-	server, key := split2byte(path[7:], '/')
-	cfg := etcd.Config{
-		Endpoints:               []string{"http://" + server},
-		Transport:               etcd.DefaultTransport,
-		// set timeout per request to fail fast when the target endpoint is unavailable
-		HeaderTimeoutPerRequest: time.Second,
+// parseEtcdPath 函数解析etcd连接地址，格式为etcd://host1,host2:2379/path/to/key?prefix=true&auth=user:pass。
+func parseEtcdPath(path string) (endpoints []string, key string, prefix bool, username, password string, err error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, "", false, "", "", err
+	}
+	endpoints = splitEtcdEndpoints(u.Host)
+	key = u.Path
+	prefix = u.Query().Get("prefix") == "true"
+	if auth := u.Query().Get("auth"); auth != "" {
+		username, password = split2byte(auth, ':')
+	}
+	return
+}
+
+// splitEtcdEndpoints 函数把u.Host按逗号拆分为多个endpoint，格式为host1,host2:2379，即只有最后一个host携带端口，
+// 前面缺省端口的host会补上该端口，避免clientv3按"host1"（无端口）去拨号。若每个host都自带端口，则原样返回。
+func splitEtcdEndpoints(host string) []string {
+	hosts := strings.Split(host, ",")
+	_, port, err := net.SplitHostPort(hosts[len(hosts)-1])
+	if err != nil {
+		return hosts
+	}
+	for i, h := range hosts {
+		if _, _, err := net.SplitHostPort(h); err != nil {
+			hosts[i] = net.JoinHostPort(h, port)
+		}
+	}
+	return hosts
+}
+
+// newEtcdClient 函数根据解析得到的etcd地址创建客户端。
+func newEtcdClient(endpoints []string, username, password string) (*clientv3.Client, error) {
+	return clientv3.New(clientv3.Config{
+		Endpoints: endpoints,
+		Username:  username,
+		Password:  password,
+	})
+}
+
+// ConfigReadEtcd 函数从etcd读取指定key的配置内容，地址格式为etcd://host1,host2:2379/path/to/key?prefix=true&auth=user:pass。
+func ConfigReadEtcd(path string) ([]byte, error) {
+	endpoints, key, prefix, username, password, err := parseEtcdPath(path)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newEtcdClient(endpoints, username, password)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	var opts []clientv3.OpOption
+	if prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	resp, err := client.Get(context.Background(), key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, fmt.Errorf("read etcd config, key '%s' is not found", key)
+	}
+	return resp.Kvs[0].Value, nil
+}
+
+// ConfigWatchEtcd 函数监听etcd指定key的变化，地址格式同ConfigReadEtcd，内容变化时调用fn，返回取消监听的函数。
+func ConfigWatchEtcd(path string, fn func([]byte)) (func(), error) {
+	endpoints, key, prefix, username, password, err := parseEtcdPath(path)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newEtcdClient(endpoints, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []clientv3.OpOption
+	if prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for resp := range client.Watch(ctx, key, opts...) {
+			for _, event := range resp.Events {
+				fn(event.Kv.Value)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		client.Close()
+	}, nil
+}
+
+// ConfigReadConsul 函数从consul读取指定key的配置内容，地址格式为consul://host:8500/path/to/key。
+func ConfigReadConsul(path string) ([]byte, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	client, err := consulapi.NewClient(&consulapi.Config{Address: u.Host})
+	if err != nil {
+		return nil, err
+	}
+
+	key := strings.TrimPrefix(u.Path, "/")
+	kv, _, err := client.KV().Get(key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if kv == nil {
+		return nil, fmt.Errorf("read consul config, key '%s' is not found", key)
+	}
+	return kv.Value, nil
+}
+
+// ConfigWatchConsul 函数使用consul的阻塞查询监听指定key的变化，地址格式同ConfigReadConsul，内容变化时调用fn，返回取消监听的函数。
+func ConfigWatchConsul(path string, fn func([]byte)) (func(), error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, err
 	}
-	c, err := etcd.New(cfg)
+	client, err := consulapi.NewClient(&consulapi.Config{Address: u.Host})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	kapi := etcd.NewKeysAPI(c)
-	resp, err := kapi.Get(context.Background(), key, nil)
-	return resp.Node.Value, err
-}*/
 
-// InitSignal 函数定义初始化系统信号。
-func InitSignal(app *Eudore) error {
-	if runtime.GOOS == "windows" || GetStringBool(os.Getenv(EnvEudoreDisableSignal)) {
+	key := strings.TrimPrefix(u.Path, "/")
+	stop := make(chan struct{})
+	go func() {
+		var index uint64
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			kv, meta, err := client.KV().Get(key, &consulapi.QueryOptions{WaitIndex: index, WaitTime: 5 * time.Minute})
+			if err != nil {
+				time.Sleep(time.Second)
+				continue
+			}
+			// 第一次查询只记录当前index，此后index变化才表示key内容发生了变化。
+			if meta.LastIndex != index {
+				if index != 0 && kv != nil {
+					fn(kv.Value)
+				}
+				index = meta.LastIndex
+			} else if kv == nil {
+				time.Sleep(time.Second)
+			}
+		}
+	}()
+
+	return func() { close(stop) }, nil
+}
+
+// InitConfigWatch 函数为'keys.configpath'读取配置使用的协议注册监听，配置内容变化时重新解析配置并触发app.OnConfigReload钩子。
+//
+// 仅当读取配置使用的协议在GlobalConfigWatchFunc中注册了对应的ConfigWatchFunc时才会生效，用于对接etcd/consul等支持watch的配置中心，使路由、日志、监听等组件可以不重启即可响应配置变化。
+func InitConfigWatch(app *Eudore) error {
+	path, ok := app.Config.Get("keys.configpath").(string)
+	if !ok || path == "" {
+		return nil
+	}
+	s := strings.SplitN(path, "://", 2)
+	fn := GlobalConfigWatchFunc[s[0]]
+	if fn == nil {
 		return nil
 	}
 
-	// Register signal
-	app.RegisterSignal(syscall.Signal(0x2), func(app *Eudore) error {
-		app.WithField("signal", 2).Info("eudore received SIGINT, eudore shutting down HTTP server.")
-		return app.Shutdown()
-	})
-	app.RegisterSignal(syscall.Signal(0xc), func(app *Eudore) error {
-		app.WithField("signal", 12).Info("eudore received SIGUSR2, eudore restarting HTTP server.")
-		return app.Restart()
-	})
-	app.RegisterSignal(syscall.Signal(0xf), func(app *Eudore) error {
-		app.WithField("signal", 15).Info("eudore received SIGTERM, eudore shutting down HTTP server.")
-		return app.Shutdown()
+	_, err := fn(path, func(data []byte) {
+		app.Config.Set("keys.configdata", envExpand(data))
+		err := ConfigParseConfig(app.Config)
+		if err != nil {
+			app.Error(err)
+			return
+		}
+		app.OnConfigReload(app.Config)
 	})
+	return err
+}
+
+// LoggerRotater 是app.Logger可选实现的接口，用于SIGUSR1信号处理器滚动日志文件。
+type LoggerRotater interface {
+	Rotate() error
+}
+
+// signalHandler 保存一个具名的信号处理器，name仅用于日志和注销时定位。
+type signalHandler struct {
+	name string
+	fn   func(*Eudore) error
+}
+
+// SignalManager 管理进程信号与其注册的具名处理器，支持同一信号注册多个处理器并单独注销。
+//
+// InitSignal在不同平台注册的默认处理器集合不同，SignalManager本身与平台无关。
+type SignalManager struct {
+	mu      sync.Mutex
+	signals map[os.Signal][]*signalHandler
+}
+
+// NewSignalManager 函数创建一个空的信号处理器注册表。
+func NewSignalManager() *SignalManager {
+	return &SignalManager{signals: make(map[os.Signal][]*signalHandler)}
+}
+
+// RegisterSignal 方法为app注册一个具名信号处理器，同一信号可重复注册多个处理器，返回值用于注销该处理器。
+//
+// app.Signals为空时会自动创建一个SignalManager，便于在InitSignal之外单独注册信号处理器。
+func (app *Eudore) RegisterSignal(sig os.Signal, name string, fn func(*Eudore) error) func() {
+	if app.Signals == nil {
+		app.Signals = NewSignalManager()
+	}
+	return app.Signals.register(sig, name, fn)
+}
+
+func (m *SignalManager) register(sig os.Signal, name string, fn func(*Eudore) error) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := &signalHandler{name: name, fn: fn}
+	m.signals[sig] = append(m.signals[sig], h)
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		hs := m.signals[sig]
+		for i, x := range hs {
+			if x == h {
+				m.signals[sig] = append(hs[:i], hs[i+1:]...)
+				return
+			}
+		}
+	}
+}
 
+// handlers 方法返回sig当前注册的全部处理器副本，用于派发时避免和注册/注销竞争。
+func (m *SignalManager) handlers(sig os.Signal) []*signalHandler {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*signalHandler{}, m.signals[sig]...)
+}
+
+// registeredSignals 方法返回当前已注册的全部信号，用于启动os/signal.Notify监听。
+func (m *SignalManager) registeredSignals() []os.Signal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sigs := make([]os.Signal, 0, len(m.signals))
+	for sig := range m.signals {
+		sigs = append(sigs, sig)
+	}
+	return sigs
+}
+
+// serve 方法监听registeredSignals返回的全部信号，依次同步调用对应信号注册的处理器，任意处理器出错都会记录到app.Error。
+func (m *SignalManager) serve(app *Eudore) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, m.registeredSignals()...)
+	for sig := range c {
+		for _, h := range m.handlers(sig) {
+			if err := h.fn(app); err != nil {
+				app.Error(fmt.Errorf("signal handler '%s' for %v error: %v", h.name, sig, err))
+			}
+		}
+	}
+}
+
+// sdNotify 函数向'NOTIFY_SOCKET'指定的unix数据报socket发送sd_notify协议状态，未设置该环境变量时不执行任何操作。
+//
+// 用于配合systemd的'Type=notify'服务单元，上报READY、STOPPING、RELOADING、WATCHDOG等状态。
+func sdNotify(state string) error {
+	socket := os.Getenv(EnvSystemdNotifySocket)
+	if socket == "" {
+		return nil
+	}
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// newSystemdListens 函数从systemd socket激活传递的'LISTEN_PID'/'LISTEN_FDS'环境变量构造net.Listener。
+//
+// 'LISTEN_PID'需要与当前进程pid匹配，否则忽略socket激活，避免子进程重复适配父进程传递的文件描述符。
+func newSystemdListens() ([]net.Listener, error) {
+	pid, _ := strconv.Atoi(os.Getenv(EnvSystemdListenPid))
+	if pid != os.Getpid() {
+		return nil, nil
+	}
+	num, _ := strconv.Atoi(os.Getenv(EnvSystemdListenFds))
+
+	lns := make([]net.Listener, 0, num)
+	for i := 0; i < num; i++ {
+		file := os.NewFile(uintptr(systemdListenFdsStart+i), "systemd-socket-"+strconv.Itoa(i))
+		ln, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return lns, err
+		}
+		lns = append(lns, ln)
+	}
+	return lns, nil
+}
+
+// InitSystemdWatchdog 函数根据'WATCHDOG_USEC'环境变量启动systemd看门狗心跳。
+//
+// 每隔watchdog周期的一半向systemd发送一次WATCHDOG=1状态，未设置该环境变量时不执行任何操作。
+func InitSystemdWatchdog(app *Eudore) error {
+	usec, err := strconv.Atoi(os.Getenv(EnvSystemdWatchdogUsec))
+	if err != nil || usec <= 0 {
+		return nil
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(usec/2) * time.Microsecond)
+		defer ticker.Stop()
+		for range ticker.C {
+			sdNotify("WATCHDOG=1")
+		}
+	}()
 	return nil
 }
 
@@ -238,6 +802,9 @@ func InitLoggerStd(app *Eudore) error {
 }
 
 // InitStart 函数启动Eudore Server。
+//
+// 先适配systemd socket激活传递的监听者，再按'listeners'配置创建全部监听者，不与已适配的监听者比对地址；
+// 全部监听者启动完成后向systemd发送READY=1状态，用于配合'Type=notify'的服务单元实现零停机重启。
 func InitStart(app *Eudore) error {
 	// 更新context func，设置server处理者。
 	if fn, ok := app.Config.Get("keys.context").(PoolGetFunc); ok {
@@ -249,6 +816,15 @@ func InitStart(app *Eudore) error {
 		app.Server.SetHandler(app)
 	}
 
+	// 适配systemd socket激活传递的文件描述符，直接使用而不重新绑定。
+	slns, err := newSystemdListens()
+	if err != nil {
+		app.Error(err)
+	}
+	for _, ln := range slns {
+		app.AddListener(ln)
+	}
+
 	// 监听全部配置
 	lns, err := newServerListens(app.Config.Get("listeners"))
 	if err != nil {
@@ -262,9 +838,238 @@ func InitStart(app *Eudore) error {
 		}
 		app.AddListener(ln)
 	}
+
+	return sdNotify("READY=1")
+}
+
+// ParamRoute 是Context.GetParam使用的参数名，保存当前请求匹配到的路由规则原始字符串，用于按路由而非原始路径统计指标。
+const ParamRoute = "route"
+
+// Metrics 定义指标采集接口，Counter、Gauge、Histogram均支持附加标签，Handler返回暴露采集结果的http.Handler。
+//
+// 中间件或业务代码可以直接调用app.Metrics注册自定义指标，而不用关心底层采集器的具体实现。
+type Metrics interface {
+	IncCounter(name string, labels map[string]string)
+	AddGauge(name string, labels map[string]string, delta float64)
+	ObserveHistogram(name string, labels map[string]string, value float64)
+	Handler() http.Handler
+}
+
+// GlobalMetricsFunc 用于创建Metrics采集器，默认使用零依赖的NewMetricsStd，可替换为statsd、OpenTelemetry等实现。
+var GlobalMetricsFunc = NewMetricsStd
+
+// InitMetrics 函数初始化指标采集系统，在app.Metrics暴露采集器，注册'/metrics'路径输出Prometheus文本格式，
+// 并挂载全局中间件按请求方法、路由(非原始路径)和状态码统计请求总数、正在处理请求数和响应延迟直方图。
+func InitMetrics(app *Eudore) error {
+	m := GlobalMetricsFunc()
+	app.Metrics = m
+	// 使用AddMiddleware挂载为全局中间件，而不是"/*"路由处理函数，
+	// 这样已注册了其他处理函数的路径也会先经过该中间件，不会被漏过统计。
+	app.AddMiddleware(newMiddlewareMetrics(m))
+	app.GetFunc("/metrics", m.Handler())
 	return nil
 }
 
+// newMiddlewareMetrics 函数创建统计请求指标的中间件，标签为method、route、status。
+func newMiddlewareMetrics(m Metrics) HandlerFunc {
+	return func(ctx Context) {
+		route := ctx.GetParam(ParamRoute)
+		method := ctx.Method()
+
+		// inflightLabels单独持有，避免labels之后追加status导致+1和-1落在不同的指标序列上。
+		inflightLabels := map[string]string{"method": method, "route": route}
+		m.AddGauge("http_requests_inflight", inflightLabels, 1)
+		defer m.AddGauge("http_requests_inflight", inflightLabels, -1)
+
+		start := time.Now()
+		ctx.Next()
+		cost := time.Since(start).Seconds()
+
+		labels := map[string]string{"method": method, "route": route, "status": strconv.Itoa(ctx.Response().Status())}
+		m.IncCounter("http_requests_total", labels)
+		m.ObserveHistogram("http_request_duration_seconds", labels, cost)
+	}
+}
+
+// metricsHistogramBuckets 定义延迟直方图的桶边界，单位秒。
+var metricsHistogramBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metricsStd 是Metrics的零依赖默认实现，使用原子操作统计计数器和Gauge，使用固定分桶直方图统计延迟分布。
+type metricsStd struct {
+	mu         sync.Mutex
+	counters   map[string]*metricsCounter
+	gauges     map[string]*metricsGauge
+	histograms map[string]*metricsHistogram
+}
+
+type metricsCounter struct {
+	name, labels string
+	value        int64
+}
+
+type metricsGauge struct {
+	name, labels string
+	value        uint64 // math.Float64bits
+}
+
+type metricsHistogram struct {
+	name, labels string
+	mu           sync.Mutex
+	buckets      []uint64
+	sum          float64
+	count        uint64
+}
+
+// NewMetricsStd 函数创建默认的零依赖Metrics采集器，使用原子计数器、Gauge和固定分桶直方图统计请求指标，并以Prometheus文本格式暴露。
+func NewMetricsStd() Metrics {
+	return &metricsStd{
+		counters:   make(map[string]*metricsCounter),
+		gauges:     make(map[string]*metricsGauge),
+		histograms: make(map[string]*metricsHistogram),
+	}
+}
+
+func (m *metricsStd) IncCounter(name string, labels map[string]string) {
+	atomic.AddInt64(&m.getCounter(name, labels).value, 1)
+}
+
+func (m *metricsStd) getCounter(name string, labels map[string]string) *metricsCounter {
+	key := metricsKey(name, labels)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.counters[key]
+	if !ok {
+		c = &metricsCounter{name: name, labels: formatLabels(labels)}
+		m.counters[key] = c
+	}
+	return c
+}
+
+func (m *metricsStd) AddGauge(name string, labels map[string]string, delta float64) {
+	g := m.getGauge(name, labels)
+	for {
+		old := atomic.LoadUint64(&g.value)
+		next := math.Float64bits(math.Float64frombits(old) + delta)
+		if atomic.CompareAndSwapUint64(&g.value, old, next) {
+			return
+		}
+	}
+}
+
+func (m *metricsStd) getGauge(name string, labels map[string]string) *metricsGauge {
+	key := metricsKey(name, labels)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g, ok := m.gauges[key]
+	if !ok {
+		g = &metricsGauge{name: name, labels: formatLabels(labels)}
+		m.gauges[key] = g
+	}
+	return g
+}
+
+func (m *metricsStd) ObserveHistogram(name string, labels map[string]string, value float64) {
+	key := metricsKey(name, labels)
+	m.mu.Lock()
+	h, ok := m.histograms[key]
+	if !ok {
+		h = &metricsHistogram{name: name, labels: formatLabels(labels), buckets: make([]uint64, len(metricsHistogramBuckets))}
+		m.histograms[key] = h
+	}
+	m.mu.Unlock()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sum += value
+	h.count++
+	for i, bound := range metricsHistogramBuckets {
+		if value <= bound {
+			h.buckets[i]++
+		}
+	}
+}
+
+// Handler 方法返回一个输出Prometheus/OpenMetrics文本格式指标的http.Handler，挂载在'/metrics'路径。
+func (m *metricsStd) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		m.mu.Lock()
+		counters := make([]*metricsCounter, 0, len(m.counters))
+		for _, c := range m.counters {
+			counters = append(counters, c)
+		}
+		gauges := make([]*metricsGauge, 0, len(m.gauges))
+		for _, g := range m.gauges {
+			gauges = append(gauges, g)
+		}
+		histograms := make([]*metricsHistogram, 0, len(m.histograms))
+		for _, h := range m.histograms {
+			histograms = append(histograms, h)
+		}
+		m.mu.Unlock()
+
+		for _, c := range counters {
+			fmt.Fprintf(w, "%s%s %d\n", c.name, c.labels, atomic.LoadInt64(&c.value))
+		}
+		for _, g := range gauges {
+			fmt.Fprintf(w, "%s%s %g\n", g.name, g.labels, math.Float64frombits(atomic.LoadUint64(&g.value)))
+		}
+		for _, h := range histograms {
+			h.mu.Lock()
+			// h.buckets[i]已经是le=bounds[i]的累计计数(ObserveHistogram对每个>=value的桶都会自增)，直接输出即可，不能再累加。
+			for i, bound := range metricsHistogramBuckets {
+				fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLe(h.labels, bound), h.buckets[i])
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLeInf(h.labels), h.count)
+			fmt.Fprintf(w, "%s_sum%s %g\n", h.name, h.labels, h.sum)
+			fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labels, h.count)
+			h.mu.Unlock()
+		}
+	})
+}
+
+// formatLabels 函数把标签map按名称排序格式化为Prometheus文本格式的'{k="v",...}'形式，无标签时返回空字符串。
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, k := range names {
+		parts[i] = fmt.Sprintf("%s=%q", k, labels[k])
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// metricsKey 函数把指标名称和标签序列化为map的key，保证相同的名称和标签组合命中同一条统计记录。
+func metricsKey(name string, labels map[string]string) string {
+	return name + formatLabels(labels)
+}
+
+// withLe 函数把直方图分桶的'le'标签合并进已有的标签文本中。
+func withLe(labels string, bound float64) string {
+	le := fmt.Sprintf("le=%q", fmt.Sprintf("%g", bound))
+	if labels == "" {
+		return "{" + le + "}"
+	}
+	return labels[:len(labels)-1] + "," + le + "}"
+}
+
+// withLeInf 函数返回'le="+Inf"'分桶的标签文本，用于直方图的最后一个累计分桶。
+func withLeInf(labels string) string {
+	le := `le="+Inf"`
+	if labels == "" {
+		return "{" + le + "}"
+	}
+	return labels[:len(labels)-1] + "," + le + "}"
+}
+
 // RouterCheckFuncIsnum 检查字符串是否为数字。
 func RouterCheckFuncIsnum(arg string) bool {
 	_, err := strconv.Atoi(arg)
@@ -298,3 +1103,252 @@ func RouterNewCheckFuncRegexp(str string) RouterCheckFunc {
 		return re.MatchString(arg)
 	}
 }
+
+// RouterNewCheckFuncMax 生成一个检查字符串最大值的RouterCheckFunc函数。
+func RouterNewCheckFuncMax(str string) RouterCheckFunc {
+	n, err := strconv.Atoi(str)
+	if err != nil {
+		return nil
+	}
+	return func(arg string) bool {
+		num, err := strconv.Atoi(arg)
+		if err != nil {
+			return false
+		}
+		return num <= n
+	}
+}
+
+// RouterNewCheckFuncRange 生成一个检查字符串数值范围的RouterCheckFunc函数，范围格式为'min-max'。
+func RouterNewCheckFuncRange(str string) RouterCheckFunc {
+	min, max, err := parseCheckIntRange(str)
+	if err != nil {
+		return nil
+	}
+	return func(arg string) bool {
+		num, err := strconv.Atoi(arg)
+		if err != nil {
+			return false
+		}
+		return num >= min && num <= max
+	}
+}
+
+// RouterNewCheckFuncLen 生成一个检查字符串长度范围的RouterCheckFunc函数，范围格式为'min-max'。
+func RouterNewCheckFuncLen(str string) RouterCheckFunc {
+	min, max, err := parseCheckIntRange(str)
+	if err != nil {
+		return nil
+	}
+	return func(arg string) bool {
+		return len(arg) >= min && len(arg) <= max
+	}
+}
+
+// parseCheckIntRange 函数解析'min-max'格式的整数范围。
+func parseCheckIntRange(str string) (int, int, error) {
+	s := strings.SplitN(str, "-", 2)
+	if len(s) != 2 {
+		return 0, 0, fmt.Errorf("invalid check range '%s', must be 'min-max'", str)
+	}
+	min, err := strconv.Atoi(s[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	max, err := strconv.Atoi(s[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return min, max, nil
+}
+
+// 预编译的uuid、email、ipv4正则表达式。
+var (
+	regexpCheckUUID  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[1-5][0-9a-fA-F]{3}-[89abAB][0-9a-fA-F]{3}-[0-9a-fA-F]{12}$`)
+	regexpCheckEmail = regexp.MustCompile(`^[\w.+-]+@[\w-]+\.[\w.-]+$`)
+	regexpCheckIPv4  = regexp.MustCompile(`^(\d{1,3}\.){3}\d{1,3}$`)
+)
+
+// RouterNewCheckFuncUUID 生成一个检查字符串是否为uuid(v1-v5)的RouterCheckFunc函数，忽略传入参数。
+func RouterNewCheckFuncUUID(string) RouterCheckFunc {
+	return func(arg string) bool {
+		return regexpCheckUUID.MatchString(arg)
+	}
+}
+
+// RouterNewCheckFuncDate 生成一个按指定时间格式检查字符串的RouterCheckFunc函数，未指定格式时使用time.RFC3339。
+func RouterNewCheckFuncDate(layout string) RouterCheckFunc {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return func(arg string) bool {
+		_, err := time.Parse(layout, arg)
+		return err == nil
+	}
+}
+
+// RouterNewCheckFuncEnum 生成一个检查字符串是否属于枚举值的RouterCheckFunc函数，枚举值使用'|'分隔。
+func RouterNewCheckFuncEnum(str string) RouterCheckFunc {
+	vals := strings.Split(str, "|")
+	return func(arg string) bool {
+		for _, val := range vals {
+			if val == arg {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RouterNewCheckFuncPrefix 生成一个检查字符串前缀的RouterCheckFunc函数。
+func RouterNewCheckFuncPrefix(str string) RouterCheckFunc {
+	return func(arg string) bool {
+		return strings.HasPrefix(arg, str)
+	}
+}
+
+// RouterNewCheckFuncSuffix 生成一个检查字符串后缀的RouterCheckFunc函数。
+func RouterNewCheckFuncSuffix(str string) RouterCheckFunc {
+	return func(arg string) bool {
+		return strings.HasSuffix(arg, str)
+	}
+}
+
+// RouterNewCheckFuncContains 生成一个检查字符串是否包含子串的RouterCheckFunc函数。
+func RouterNewCheckFuncContains(str string) RouterCheckFunc {
+	return func(arg string) bool {
+		return strings.Contains(arg, str)
+	}
+}
+
+// RouterCheckFuncEmail 检查字符串是否为合法邮箱地址。
+func RouterCheckFuncEmail(arg string) bool {
+	return regexpCheckEmail.MatchString(arg)
+}
+
+// RouterCheckFuncIPv4 检查字符串是否为合法ipv4地址。
+func RouterCheckFuncIPv4(arg string) bool {
+	return regexpCheckIPv4.MatchString(arg) && net.ParseIP(arg) != nil
+}
+
+// RouterCheckFuncIPv6 检查字符串是否为合法ipv6地址。
+func RouterCheckFuncIPv6(arg string) bool {
+	return strings.Contains(arg, ":") && net.ParseIP(arg) != nil
+}
+
+// checkFuncCache 缓存RouterCheckFuncCompile编译结果，key为原始表达式字符串，避免同一路由重复解析。
+var (
+	checkFuncCache      = make(map[string]RouterCheckFunc)
+	checkFuncCacheMutex sync.RWMutex
+)
+
+// RouterCheckFuncCompile 函数编译path参数校验表达式，支持GlobalRouterCheckFunc/GlobalRouterNewCheckFunc注册的校验器，
+// 以及'and(...)'、'or(...)'、'not(...)'组合校验器，例如'uuid'、'min:1'、'or(min:1,enum:latest)'，编译结果按表达式文本缓存。
+func RouterCheckFuncCompile(expr string) RouterCheckFunc {
+	checkFuncCacheMutex.RLock()
+	fn, ok := checkFuncCache[expr]
+	checkFuncCacheMutex.RUnlock()
+	if ok {
+		return fn
+	}
+
+	fn = compileCheckExpr(expr)
+	checkFuncCacheMutex.Lock()
+	checkFuncCache[expr] = fn
+	checkFuncCacheMutex.Unlock()
+	return fn
+}
+
+// compileCheckExpr 函数递归解析单个校验表达式，支持'name'、'name:arg'和组合校验器'and(expr,expr)'。
+func compileCheckExpr(expr string) RouterCheckFunc {
+	expr = strings.TrimSpace(expr)
+	name, arg := splitCheckExpr(expr)
+
+	switch name {
+	case "and", "or", "not":
+		var fns []RouterCheckFunc
+		for _, child := range splitCheckArgs(arg) {
+			if fn := compileCheckExpr(child); fn != nil {
+				fns = append(fns, fn)
+			}
+		}
+		return combineCheckFuncs(name, fns)
+	}
+
+	if newFn, ok := GlobalRouterNewCheckFunc[name]; ok {
+		return newFn(arg)
+	}
+	return GlobalRouterCheckFunc[name]
+}
+
+// splitCheckExpr 函数拆分'name:arg'或'name(arg)'形式的表达式为名称和参数两部分。
+func splitCheckExpr(expr string) (string, string) {
+	pos := strings.IndexAny(expr, ":(")
+	if pos == -1 {
+		return expr, ""
+	}
+	if expr[pos] == ':' {
+		return expr[:pos], expr[pos+1:]
+	}
+	if strings.HasSuffix(expr, ")") {
+		return expr[:pos], expr[pos+1 : len(expr)-1]
+	}
+	return expr[:pos], expr[pos+1:]
+}
+
+// splitCheckArgs 函数按顶层逗号拆分组合校验器的子表达式，忽略嵌套括号内的逗号。
+func splitCheckArgs(str string) []string {
+	var args []string
+	depth, start := 0, 0
+	for i, r := range str {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(str[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if start < len(str) {
+		args = append(args, strings.TrimSpace(str[start:]))
+	}
+	return args
+}
+
+// combineCheckFuncs 函数按and/or/not语义组合多个RouterCheckFunc。
+func combineCheckFuncs(name string, fns []RouterCheckFunc) RouterCheckFunc {
+	switch name {
+	case "and":
+		return func(arg string) bool {
+			for _, fn := range fns {
+				if fn == nil || !fn(arg) {
+					return false
+				}
+			}
+			return true
+		}
+	case "or":
+		return func(arg string) bool {
+			for _, fn := range fns {
+				if fn != nil && fn(arg) {
+					return true
+				}
+			}
+			return false
+		}
+	case "not":
+		return func(arg string) bool {
+			for _, fn := range fns {
+				if fn != nil && fn(arg) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+//go:build !windows
+
+package eudore
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// InitSignal 函数初始化信号处理：创建app.Signals信号管理器，注册默认的信号处理器，然后启动监听循环。
+//
+// 默认处理器：SIGINT/SIGTERM优雅关闭HTTP服务；SIGUSR2重启HTTP服务；SIGHUP重新解析配置并触发app.OnConfigReload；
+// SIGUSR1调用app.Logger的LoggerRotater接口滚动日志文件；SIGQUIT在关闭前把全部goroutine堆栈dump到日志。
+func InitSignal(app *Eudore) error {
+	if GetStringBool(os.Getenv(EnvEudoreDisableSignal)) {
+		return nil
+	}
+
+	app.RegisterSignal(syscall.SIGINT, "shutdown", func(app *Eudore) error {
+		app.WithField("signal", "SIGINT").Info("eudore received SIGINT, eudore shutting down HTTP server.")
+		sdNotify("STOPPING=1")
+		return app.Shutdown()
+	})
+	app.RegisterSignal(syscall.SIGTERM, "shutdown", func(app *Eudore) error {
+		app.WithField("signal", "SIGTERM").Info("eudore received SIGTERM, eudore shutting down HTTP server.")
+		sdNotify("STOPPING=1")
+		return app.Shutdown()
+	})
+	app.RegisterSignal(syscall.SIGUSR2, "restart", func(app *Eudore) error {
+		app.WithField("signal", "SIGUSR2").Info("eudore received SIGUSR2, eudore restarting HTTP server.")
+		sdNotify("RELOADING=1")
+		err := app.Restart()
+		sdNotify("READY=1")
+		return err
+	})
+	app.RegisterSignal(syscall.SIGHUP, "config-reload", func(app *Eudore) error {
+		app.WithField("signal", "SIGHUP").Info("eudore received SIGHUP, reloading config.")
+		err := app.Config.Parse()
+		if err != nil {
+			return err
+		}
+		app.OnConfigReload(app.Config)
+		return nil
+	})
+	app.RegisterSignal(syscall.SIGUSR1, "log-rotate", func(app *Eudore) error {
+		app.WithField("signal", "SIGUSR1").Info("eudore received SIGUSR1, rotating log files.")
+		rotater, ok := app.Logger.(LoggerRotater)
+		if !ok {
+			return fmt.Errorf("logger %T does not implement LoggerRotater, cannot rotate", app.Logger)
+		}
+		return rotater.Rotate()
+	})
+	app.RegisterSignal(syscall.SIGQUIT, "dump-stack", func(app *Eudore) error {
+		buf := make([]byte, 1<<20)
+		buf = buf[:runtime.Stack(buf, true)]
+		app.WithField("signal", "SIGQUIT").Info(string(buf))
+		sdNotify("STOPPING=1")
+		return app.Shutdown()
+	})
+
+	go app.Signals.serve(app)
+	return nil
+}
@@ -0,0 +1,90 @@
+//go:build windows
+
+package eudore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	winio "github.com/Microsoft/go-winio"
+)
+
+// EudoreSignalPipeName 是Windows下信号控制管道的名称，外部进程连接该管道并按行写入'reload'等指令触发对应处理器。
+// '--signal=xxx'命令行参数只会被ConfigParseArgs设置为普通配置项，不会写入该管道。
+const EudoreSignalPipeName = `\\.\pipe\eudore-signal`
+
+// InitSignal 函数初始化信号处理：Windows不支持SIGHUP/SIGUSR1/SIGQUIT等unix信号，
+// 因此仅对os.Interrupt注册优雅关闭处理器，并额外启动一个具名管道控制通道，按行接收'reload'、'restart'、'log-rotate'文本指令，
+// 分别对应unix下SIGHUP、SIGUSR2、SIGUSR1的处理。
+func InitSignal(app *Eudore) error {
+	if GetStringBool(os.Getenv(EnvEudoreDisableSignal)) {
+		return nil
+	}
+
+	app.RegisterSignal(os.Interrupt, "shutdown", func(app *Eudore) error {
+		app.WithField("signal", "os.Interrupt").Info("eudore received interrupt, eudore shutting down HTTP server.")
+		return app.Shutdown()
+	})
+
+	go app.Signals.serve(app)
+	go serveSignalPipe(app)
+	return nil
+}
+
+// signalPipeCommands 保存具名管道接收到的文本指令到处理函数的映射，与unix下的SIGHUP/SIGUSR2/SIGUSR1含义对应。
+var signalPipeCommands = map[string]func(*Eudore) error{
+	"reload": func(app *Eudore) error {
+		app.WithField("signal", "reload").Info("eudore received reload command, reloading config.")
+		err := app.Config.Parse()
+		if err != nil {
+			return err
+		}
+		app.OnConfigReload(app.Config)
+		return nil
+	},
+	"restart": func(app *Eudore) error {
+		app.WithField("signal", "restart").Info("eudore received restart command, eudore restarting HTTP server.")
+		return app.Restart()
+	},
+	"log-rotate": func(app *Eudore) error {
+		rotater, ok := app.Logger.(LoggerRotater)
+		if !ok {
+			return fmt.Errorf("logger %T does not implement LoggerRotater, cannot rotate", app.Logger)
+		}
+		return rotater.Rotate()
+	},
+}
+
+// serveSignalPipe 函数监听EudoreSignalPipeName具名管道，把每行收到的文本指令分发给signalPipeCommands对应的处理函数。
+func serveSignalPipe(app *Eudore) {
+	ln, err := winio.ListenPipe(EudoreSignalPipeName, nil)
+	if err != nil {
+		app.Error(fmt.Errorf("listen signal pipe '%s' error: %v", EudoreSignalPipeName, err))
+		return
+	}
+	defer ln.Close()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			app.Error(err)
+			continue
+		}
+		go func() {
+			defer conn.Close()
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				cmd := strings.TrimSpace(scanner.Text())
+				fn, ok := signalPipeCommands[cmd]
+				if !ok {
+					continue
+				}
+				if err := fn(app); err != nil {
+					app.Error(fmt.Errorf("signal pipe command '%s' error: %v", cmd, err))
+				}
+			}
+		}()
+	}
+}